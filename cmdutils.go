@@ -1,11 +1,34 @@
 package cmdutils
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
 	"sync"
+	"time"
+)
+
+// KillGrace is how long ExecuteContext/AsyncExecuteContext wait after
+// sending os.Interrupt before force-killing a command whose context was
+// canceled.
+const KillGrace = 5 * time.Second
+
+const (
+	// DefaultOutputChanBuffer is the default buffer size of the channel
+	// AsyncExecute/AsyncExecuteContext return.
+	DefaultOutputChanBuffer = 10
+
+	// DefaultScannerBuffer is the default initial size of the line
+	// scanner buffer used by AsyncExecute/AsyncExecuteContext.
+	DefaultScannerBuffer = 64 * 1024
+
+	// DefaultMaxScannerBuffer is the default maximum size the line
+	// scanner buffer is allowed to grow to before a line is reported as
+	// too long.
+	DefaultMaxScannerBuffer = 1024 * 1024
 )
 
 type CLI uint8
@@ -21,11 +44,23 @@ type Executer interface {
 	// Execute command with selected executer
 	Execute(command string, flags ...string) (string, error)
 
+	// Execute command with selected executer, honoring ctx for cancellation
+	// and timeout and applying any ExecOption (env, working directory,
+	// stdin/stdout/stderr sinks).
+	//
+	// When ctx is canceled or its deadline expires, the command is sent
+	// os.Interrupt and, if it hasn't exited after KillGrace, Kill.
+	ExecuteContext(ctx context.Context, command string, opts ...ExecOption) (string, error)
+
 	// Execute commands with selected executer and get results asynchronously
 	AsyncExecute(command string, flags ...string) (chan OutputMessage, error)
 
+	// Execute commands with selected executer and get results asynchronously,
+	// honoring ctx for cancellation and applying any ExecOption.
+	AsyncExecuteContext(ctx context.Context, command string, opts ...ExecOption) (chan OutputMessage, error)
+
 	// Clear console output
-	Clear()
+	Clear() error
 
 	// Make directory with given name and permissions
 	//
@@ -42,6 +77,32 @@ type Executer interface {
 	// Remove file or directory of given path
 	Rm(path string) error
 
+	// Remove path and, if it is a directory, everything inside it
+	RmAll(path string) error
+
+	// Copy src to dst; see CopyOption for recursive, mode-preserving and
+	// symlink-following behavior
+	Cp(src, dst string, opts ...CopyOption) error
+
+	// Move src to dst
+	Mv(src, dst string) error
+
+	// Change the permissions of path
+	//
+	// Permission is 755 by default, but you can change permissions with arguments like this:
+	// perm[0] for owner, perm[1] for group and perm[2] for others.
+	Chmod(path string, perm ...PermissionMode) error
+
+	// Create newname as a symbolic link to oldname
+	Symlink(oldname, newname string) error
+
+	// Report whether path exists
+	Exists(path string) (bool, error)
+
+	// Chain Commands together, wiring each stage's stdout into the next
+	// stage's stdin without invoking a shell
+	Pipeline(cmds ...*Command) *Pipeline
+
 	// Debug mode
 	Debug()
 }
@@ -97,17 +158,94 @@ func (e *executer) Execute(command string, flags ...string) (string, error) {
 	cmd := exec.Command(e.cliExecuter, e.cliParams, command)
 	cmd.Args = append(cmd.Args, flags...)
 
+	stderr := newRingBuffer(MaxCapturedStderr)
+	cmd.Stderr = stderr
+
 	if e.isDebug {
 		cmd.Stdin = os.Stdin
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = io.MultiWriter(stderr, os.Stderr)
 	}
 
+	start := time.Now()
 	output, err := cmd.Output()
-	if err != nil {
-		log.Printf("Couldn't Run Command << %s >>\nerror: %s", command, err.Error())
+	duration := time.Since(start)
+
+	return string(output), wrapCommandError(command, stderr.Bytes(), duration, err)
+}
+
+// Execute command with selected executer, honoring ctx for cancellation
+// and timeout and applying any ExecOption (env, working directory,
+// stdin/stdout/stderr sinks).
+//
+// When ctx is canceled or its deadline expires, the command is sent
+// os.Interrupt and, if it hasn't exited after KillGrace, Kill.
+func (e *executer) ExecuteContext(ctx context.Context, command string, opts ...ExecOption) (string, error) {
+	cfg := &execConfig{}
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
-	return string(output), err
+	cmd := exec.Command(e.cliExecuter, e.cliParams, command)
+	if cfg.env != nil {
+		cmd.Env = cfg.env
+	}
+	if cfg.dir != "" {
+		cmd.Dir = cfg.dir
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if cfg.stdout != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, cfg.stdout)
+	}
+
+	stderr := newRingBuffer(MaxCapturedStderr)
+	cmd.Stderr = stderr
+	if cfg.stderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, cfg.stderr)
+	}
+
+	switch {
+	case cfg.stdin != nil:
+		cmd.Stdin = cfg.stdin
+	case e.isDebug:
+		cmd.Stdin = os.Stdin
+	}
+
+	if e.isDebug && cfg.stderr == nil {
+		cmd.Stderr = io.MultiWriter(stderr, os.Stderr)
+	}
+
+	start := time.Now()
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	wrap := func(err error) error {
+		return wrapCommandError(command, stderr.Bytes(), time.Since(start), err)
+	}
+
+	select {
+	case err := <-done:
+		return stdout.String(), wrap(err)
+
+	case <-ctx.Done():
+		_ = cmd.Process.Signal(os.Interrupt)
+
+		select {
+		case err := <-done:
+			return stdout.String(), wrap(err)
+		case <-time.After(KillGrace):
+			_ = cmd.Process.Kill()
+			err := <-done
+
+			return stdout.String(), wrap(err)
+		}
+	}
 }
 
 // Set to Debug mode
@@ -116,7 +254,7 @@ func (e *executer) Debug() {
 }
 
 // Clear console output
-func (e *executer) Clear() {
+func (e *executer) Clear() error {
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		cmd = exec.Command("cmd", "/c", "cls")
@@ -125,9 +263,8 @@ func (e *executer) Clear() {
 	}
 
 	cmd.Stdout = os.Stdout
-	if err := cmd.Run(); err != nil {
-		log.Fatal("Couldn't Clear Terminal: ", err)
-	}
+
+	return cmd.Run()
 }
 
 // Make directory with given name and permissions
@@ -178,104 +315,146 @@ type OutputMessage struct {
 
 // Execute commands with selected executer and get results asynchronously
 func (e *executer) AsyncExecute(command string, flags ...string) (chan OutputMessage, error) {
+	return e.asyncExecute(context.Background(), command, flags, nil)
+}
+
+// Execute commands with selected executer and get results asynchronously,
+// honoring ctx for cancellation and applying any ExecOption.
+//
+// When ctx is canceled, the command is sent os.Interrupt and, if it hasn't
+// exited after KillGrace, Kill.
+func (e *executer) AsyncExecuteContext(ctx context.Context, command string, opts ...ExecOption) (chan OutputMessage, error) {
+	return e.asyncExecute(ctx, command, nil, opts)
+}
+
+// asyncExecute is the shared implementation behind AsyncExecute and
+// AsyncExecuteContext. It scans stdout/stderr line by line with
+// bufio.Scanner instead of reading one byte at a time, grows the scan
+// buffer up to a configurable maximum for long lines, and never reports
+// a plain EOF as an OutputMessage error.
+func (e *executer) asyncExecute(ctx context.Context, command string, flags []string, opts []ExecOption) (chan OutputMessage, error) {
+	cfg := &execConfig{
+		chanBuffer:    DefaultOutputChanBuffer,
+		scannerBuf:    DefaultScannerBuffer,
+		scannerMaxBuf: DefaultMaxScannerBuffer,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	cmd := exec.Command(e.cliExecuter, e.cliParams, command)
 	cmd.Args = append(cmd.Args, flags...)
 
-	if e.isDebug {
-		cmd.Stdin = os.Stdin
-		cmd.Stderr = os.Stderr
+	if cfg.env != nil {
+		cmd.Env = cfg.env
+	}
+	if cfg.dir != "" {
+		cmd.Dir = cfg.dir
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+	switch {
+	case cfg.stdin != nil:
+		cmd.Stdin = cfg.stdin
+	case e.isDebug:
+		cmd.Stdin = os.Stdin
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
+	output := make(chan OutputMessage, cfg.chanBuffer)
+	stderrCapture := newRingBuffer(MaxCapturedStderr)
+
+	var wg sync.WaitGroup
+
+	scan := func(pr *io.PipeReader, isStderr, capture bool) {
+		defer wg.Done()
+
+		var capTo io.Writer
+		if capture {
+			capTo = stderrCapture
+		}
+
+		scanLines(pr, isStderr, capTo, output, cfg.scannerBuf, cfg.scannerMaxBuf)
 	}
 
-	err = cmd.Start()
-	if err != nil {
-		return nil, err
+	// Route both stdout and stderr through io.Pipe rather than
+	// cmd.StdoutPipe()/StderrPipe(): those raw pipes are closed by
+	// cmd.Wait() as soon as the process is reaped, which os/exec
+	// documents as unsafe to do before the reader goroutine has drained
+	// them. Setting cmd.Stdout/Stderr to an io.Writer instead makes
+	// cmd.Wait() block until everything has been copied into it, so it's
+	// safe for us to Close() the writer right after Wait() returns.
+	var stdoutWriter, stderrWriter *io.PipeWriter
+
+	// WithStdout/WithStderr sinks get a copy of the raw stream alongside
+	// the line scanner.
+	tee := func(pw *io.PipeWriter, sink io.Writer) io.Writer {
+		if sink == nil {
+			return pw
+		}
+
+		return io.MultiWriter(pw, sink)
 	}
 
-	stderrBuffer := make([]byte, 1)
-	stdoutBuffer := make([]byte, 1)
-	output := make(chan OutputMessage, 10)
+	if cfg.mergeOutput {
+		pipeReader, pipeWriter := io.Pipe()
+		cmd.Stdout = tee(pipeWriter, cfg.stdout)
+		cmd.Stderr = tee(pipeWriter, cfg.stderr)
+		stdoutWriter = pipeWriter
 
-	var wg sync.WaitGroup
+		wg.Add(1)
+		go scan(pipeReader, false, true)
+	} else {
+		stdoutReader, pipeWriter := io.Pipe()
+		cmd.Stdout = tee(pipeWriter, cfg.stdout)
+		stdoutWriter = pipeWriter
 
-	wg.Add(1)
-	// pipe for stderr
-	go func() {
-	console:
-		for {
-			var line string
-		line:
-			for {
-				_, err := stderr.Read(stderrBuffer)
-				if err != nil {
-					output <- OutputMessage{Error: err}
-
-					break console
-				}
-
-				if string(stderrBuffer) == "\n" {
-					break line
-				}
-
-				line += string(stderrBuffer)
-			}
+		stderrReader, errPipeWriter := io.Pipe()
+		cmd.Stderr = tee(errPipeWriter, cfg.stderr)
+		stderrWriter = errPipeWriter
 
-			output <- OutputMessage{Line: line, IsStderr: true}
+		wg.Add(2)
+		go scan(stdoutReader, false, false)
+		go scan(stderrReader, true, true)
+	}
 
-			line = ""
-		}
+	start := time.Now()
 
-		wg.Done()
-	}()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	procDone := make(chan struct{})
 
-	wg.Add(1)
-	// pipe for stdout
 	go func() {
-	console:
-		for {
-			var line string
-		line:
-			for {
-				_, err := stdout.Read(stdoutBuffer)
-				if err != nil {
-					output <- OutputMessage{Error: err}
-
-					break console
-				}
-
-				if string(stdoutBuffer) == "\n" {
-					break line
-				}
-
-				line += string(stdoutBuffer)
+		select {
+		case <-ctx.Done():
+			_ = cmd.Process.Signal(os.Interrupt)
+
+			select {
+			case <-time.After(KillGrace):
+				_ = cmd.Process.Kill()
+			case <-procDone:
 			}
-
-			output <- OutputMessage{Line: line}
-
-			line = ""
+		case <-procDone:
 		}
-
-		wg.Done()
 	}()
 
-	// pipe for wait and close
 	go func() {
 		err := cmd.Wait()
-		if err != nil {
-			output <- OutputMessage{Error: err}
+		close(procDone)
+
+		stdoutWriter.Close()
+		if stderrWriter != nil {
+			stderrWriter.Close()
 		}
 
+		// Wait for the scan goroutines to drain stdout/stderr (and finish
+		// writing to stderrCapture) before reading it for the error below.
 		wg.Wait()
 
+		if err != nil {
+			output <- OutputMessage{Error: wrapCommandError(command, stderrCapture.Bytes(), time.Since(start), err)}
+		}
+
 		close(output)
 	}()
 
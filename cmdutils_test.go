@@ -0,0 +1,47 @@
+package cmdutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteContext_EnvAndDir(t *testing.T) {
+	dir := t.TempDir()
+
+	e := NewExecuter(CLI_BASH)
+
+	out, err := e.ExecuteContext(context.Background(), "echo $FOO; pwd",
+		WithEnv(append([]string{"FOO=bar"}, "PATH=/usr/bin:/bin")),
+		WithDir(dir),
+	)
+	if err != nil {
+		t.Fatalf("ExecuteContext: %v", err)
+	}
+
+	if !strings.Contains(out, "bar") {
+		t.Errorf("output %q does not contain env var value", out)
+	}
+	if !strings.Contains(out, dir) {
+		t.Errorf("output %q does not contain working directory %q", out, dir)
+	}
+}
+
+func TestExecuteContext_CancelKillsProcess(t *testing.T) {
+	e := NewExecuter(CLI_BASH)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := e.ExecuteContext(ctx, "sleep 30")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected an error from a command killed by context cancellation")
+	}
+	if elapsed >= 30*time.Second {
+		t.Fatalf("ExecuteContext did not return early, took %s", elapsed)
+	}
+}
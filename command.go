@@ -0,0 +1,34 @@
+package cmdutils
+
+import "io"
+
+// Command is a single program invocation: its argv, environment, working
+// directory and an optional stdin override. Pipeline wires multiple
+// Commands together without invoking a shell. Execute/ExecuteContext
+// predate Command and are unrelated: they still take a single shell
+// command line and build an exec.Cmd straight from it, not a Command.
+type Command struct {
+	// Name is the program to run, e.g. "grep". It is looked up on PATH
+	// the same way exec.Command does.
+	Name string
+
+	// Args are the program's arguments, not including Name.
+	Args []string
+
+	// Env overrides the process environment; nil inherits the current
+	// process environment.
+	Env []string
+
+	// Dir sets the working directory; empty uses the current one.
+	Dir string
+
+	// Stdin overrides the command's standard input. In a Pipeline this
+	// is only honored for the first stage; later stages read from the
+	// previous stage's stdout.
+	Stdin io.Reader
+}
+
+// NewCommand builds a Command for name with the given arguments.
+func NewCommand(name string, args ...string) *Command {
+	return &Command{Name: name, Args: args}
+}
@@ -0,0 +1,103 @@
+package cmdutils
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// MaxCapturedStderr bounds how much of a failed command's stderr
+// CommandError keeps, so a chatty command can't make a single error
+// balloon in memory.
+const MaxCapturedStderr = 64 * 1024
+
+// CommandError is returned by Execute/ExecuteContext/AsyncExecute when the
+// underlying command exits non-zero. It wraps the *exec.ExitError and
+// carries the command line, its duration, and the last bytes of stderr
+// captured while it ran.
+type CommandError struct {
+	command  string
+	exitErr  *exec.ExitError
+	stderr   []byte
+	duration time.Duration
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("command %q failed: %s", e.command, e.exitErr)
+	if len(e.stderr) > 0 {
+		msg += fmt.Sprintf("\nstderr:\n%s", e.stderr)
+	}
+
+	return msg
+}
+
+// Unwrap exposes the underlying *exec.ExitError for errors.As/errors.Is.
+func (e *CommandError) Unwrap() error {
+	return e.exitErr
+}
+
+// ExitCode returns the command's exit status.
+func (e *CommandError) ExitCode() int {
+	return e.exitErr.ExitCode()
+}
+
+// Stderr returns the last MaxCapturedStderr bytes of the command's
+// standard error.
+func (e *CommandError) Stderr() []byte {
+	return e.stderr
+}
+
+// Command returns the command line that failed.
+func (e *CommandError) Command() string {
+	return e.command
+}
+
+// Duration returns how long the command ran before exiting.
+func (e *CommandError) Duration() time.Duration {
+	return e.duration
+}
+
+// wrapCommandError turns err into a *CommandError when it is an
+// *exec.ExitError, carrying command, the captured stderr and duration
+// along with it. Any other error (e.g. a start failure) is returned
+// unchanged.
+func wrapCommandError(command string, stderr []byte, duration time.Duration, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	return &CommandError{
+		command:  command,
+		exitErr:  exitErr,
+		stderr:   stderr,
+		duration: duration,
+	}
+}
+
+// ringBuffer is an io.Writer that keeps only the last max bytes written to
+// it, so stderr can be captured unconditionally without risking unbounded
+// memory use.
+type ringBuffer struct {
+	max int
+	buf []byte
+}
+
+func newRingBuffer(max int) *ringBuffer {
+	return &ringBuffer{max: max}
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+
+	if len(r.buf) > r.max {
+		r.buf = r.buf[len(r.buf)-r.max:]
+	}
+
+	return len(p), nil
+}
+
+func (r *ringBuffer) Bytes() []byte {
+	return r.buf
+}
@@ -0,0 +1,48 @@
+package cmdutils
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecuteContext_ReturnsCommandErrorOnNonZeroExit(t *testing.T) {
+	e := NewExecuter(CLI_BASH)
+
+	_, err := e.ExecuteContext(context.Background(), "echo oops >&2; exit 7")
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("err = %v, want *CommandError", err)
+	}
+
+	if cmdErr.ExitCode() != 7 {
+		t.Errorf("ExitCode() = %d, want 7", cmdErr.ExitCode())
+	}
+	if !strings.Contains(string(cmdErr.Stderr()), "oops") {
+		t.Errorf("Stderr() = %q, want it to contain %q", cmdErr.Stderr(), "oops")
+	}
+	if cmdErr.Command() != "echo oops >&2; exit 7" {
+		t.Errorf("Command() = %q", cmdErr.Command())
+	}
+	if cmdErr.Duration() <= 0 {
+		t.Errorf("Duration() = %v, want > 0", cmdErr.Duration())
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(cmdErr, &exitErr) {
+		t.Error("errors.As should find the wrapped *exec.ExitError via Unwrap")
+	}
+}
+
+func TestRingBuffer_KeepsOnlyLastMaxBytes(t *testing.T) {
+	buf := newRingBuffer(4)
+
+	buf.Write([]byte("abcdef"))
+
+	if got := string(buf.Bytes()); got != "cdef" {
+		t.Errorf("Bytes() = %q, want %q", got, "cdef")
+	}
+}
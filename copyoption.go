@@ -0,0 +1,35 @@
+package cmdutils
+
+// CopyOption configures a single Cp invocation.
+type CopyOption func(*copyConfig)
+
+type copyConfig struct {
+	recursive      bool
+	preserveMode   bool
+	followSymlinks bool
+}
+
+// WithRecursive makes Cp copy directories (and their contents) instead of
+// failing when src is a directory.
+func WithRecursive(recursive bool) CopyOption {
+	return func(c *copyConfig) {
+		c.recursive = recursive
+	}
+}
+
+// WithPreserveMode makes Cp give copied files/directories the same
+// permission bits as their source instead of the destination's default
+// mode.
+func WithPreserveMode(preserve bool) CopyOption {
+	return func(c *copyConfig) {
+		c.preserveMode = preserve
+	}
+}
+
+// WithFollowSymlinks makes Cp copy the file a symlink points to instead
+// of recreating the symlink itself at the destination.
+func WithFollowSymlinks(follow bool) CopyOption {
+	return func(c *copyConfig) {
+		c.followSymlinks = follow
+	}
+}
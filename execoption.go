@@ -0,0 +1,86 @@
+package cmdutils
+
+import "io"
+
+// ExecOption configures a single ExecuteContext/AsyncExecuteContext
+// invocation.
+type ExecOption func(*execConfig)
+
+type execConfig struct {
+	env    []string
+	dir    string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	chanBuffer    int
+	scannerBuf    int
+	scannerMaxBuf int
+	mergeOutput   bool
+}
+
+// WithEnv overrides the environment passed to the spawned command.
+// When unset, the command inherits the current process environment.
+func WithEnv(env []string) ExecOption {
+	return func(c *execConfig) {
+		c.env = env
+	}
+}
+
+// WithDir sets the working directory the command is run from.
+func WithDir(dir string) ExecOption {
+	return func(c *execConfig) {
+		c.dir = dir
+	}
+}
+
+// WithStdin sets the reader the command reads its stdin from.
+func WithStdin(stdin io.Reader) ExecOption {
+	return func(c *execConfig) {
+		c.stdin = stdin
+	}
+}
+
+// WithStdout sets the writer the command's stdout is copied to, in
+// addition to it being captured and returned.
+func WithStdout(stdout io.Writer) ExecOption {
+	return func(c *execConfig) {
+		c.stdout = stdout
+	}
+}
+
+// WithStderr sets the writer the command's stderr is copied to, in
+// addition to it being captured for error reporting.
+func WithStderr(stderr io.Writer) ExecOption {
+	return func(c *execConfig) {
+		c.stderr = stderr
+	}
+}
+
+// WithOutputChanBuffer sets the buffer size of the channel AsyncExecute /
+// AsyncExecuteContext return. Defaults to DefaultOutputChanBuffer.
+func WithOutputChanBuffer(size int) ExecOption {
+	return func(c *execConfig) {
+		c.chanBuffer = size
+	}
+}
+
+// WithScannerBuffer sets the initial and maximum buffer size used to scan
+// a streamed command's output lines. A line longer than initial grows the
+// buffer up to max before being reported as bufio.ErrTooLong. Defaults to
+// DefaultScannerBuffer and DefaultMaxScannerBuffer.
+func WithScannerBuffer(initial, max int) ExecOption {
+	return func(c *execConfig) {
+		c.scannerBuf = initial
+		c.scannerMaxBuf = max
+	}
+}
+
+// WithMergedOutput makes AsyncExecuteContext interleave
+// stdout and stderr onto a single stream instead of reporting them
+// separately; every OutputMessage.IsStderr is then false.
+func WithMergedOutput(merge bool) ExecOption {
+	return func(c *execConfig) {
+		c.mergeOutput = merge
+	}
+}
@@ -0,0 +1,204 @@
+package cmdutils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// Copy src to dst using os/io only, without shelling out to cp/xcopy.
+//
+// By default a directory src is an error and a symlink src is recreated as
+// a symlink at dst. Pass WithRecursive(true) to copy a directory and its
+// contents, WithPreserveMode(true) to give dst the same permission bits as
+// src (copied from src's os.FileMode; best-effort on Windows, where only
+// the read-only bit applies), and WithFollowSymlinks(true) to copy the
+// file a symlink points to instead of recreating the symlink at dst.
+func (e *executer) Cp(src, dst string, opts ...CopyOption) error {
+	cfg := &copyConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return copyPath(src, dst, cfg)
+}
+
+func copyPath(src, dst string, cfg *copyConfig) error {
+	info, err := lstatOrStat(src, cfg.followSymlinks)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return copySymlink(src, dst, info, cfg)
+	case info.IsDir():
+		return copyDir(src, dst, info, cfg)
+	default:
+		return copyFile(src, dst, info, cfg)
+	}
+}
+
+func lstatOrStat(path string, followSymlinks bool) (os.FileInfo, error) {
+	if followSymlinks {
+		return os.Stat(path)
+	}
+
+	return os.Lstat(path)
+}
+
+func copyFile(src, dst string, info os.FileInfo, cfg *copyConfig) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	mode := os.FileMode(0644)
+	if cfg.preserveMode {
+		mode = info.Mode()
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if cfg.preserveMode {
+		// OpenFile's mode is filtered by the umask and ignored for an
+		// existing dst, so set the bits explicitly.
+		return os.Chmod(dst, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+func copySymlink(src, dst string, info os.FileInfo, cfg *copyConfig) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(target, dst)
+}
+
+func copyDir(src, dst string, info os.FileInfo, cfg *copyConfig) error {
+	if !cfg.recursive {
+		return &os.PathError{Op: "cp", Path: src, Err: os.ErrInvalid}
+	}
+
+	mode := os.FileMode(0755)
+	if cfg.preserveMode {
+		mode = info.Mode()
+	}
+
+	if err := os.MkdirAll(dst, mode); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.preserveMode {
+		// MkdirAll's mode is filtered by the umask and ignored for an
+		// existing dst; set it after the contents so a read-only src
+		// directory doesn't block copying into dst.
+		return os.Chmod(dst, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+// Move src to dst.
+//
+// This is a plain os.Rename on the common case, same filesystem. When src
+// and dst straddle different filesystems/mounts (e.g. separate Docker
+// volumes), os.Rename fails with EXDEV; fall back to a recursive Cp
+// followed by removing src.
+func (e *executer) Mv(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if cpErr := e.Cp(src, dst, WithRecursive(true), WithPreserveMode(true)); cpErr != nil {
+		return cpErr
+	}
+
+	return e.RmAll(src)
+}
+
+// Change the permissions of path.
+//
+// Permission is 755 by default, but you can change permissions with
+// arguments like this: perm[0] for owner, perm[1] for group and perm[2]
+// for others, the same convention Mkdir uses.
+//
+// On Windows, POSIX permission bits aren't meaningful; only the owner
+// write bit is honored, toggling the file's read-only attribute.
+func (e *executer) Chmod(path string, perm ...PermissionMode) error {
+	p := [3]PermissionMode{Perm_rwx, Perm_rox, Perm_rox}
+
+	for i, val := range perm {
+		if i < len(p) {
+			p[i] = val
+		}
+	}
+
+	permBits, err := mergePerm(p[0], p[1], p[2])
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(permBits)
+	if runtime.GOOS == "windows" {
+		if p[0]&Perm_owo == 0 {
+			mode = 0444
+		} else {
+			mode = 0666
+		}
+	}
+
+	return os.Chmod(path, mode)
+}
+
+// Create newname as a symbolic link to oldname.
+func (e *executer) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+// Remove path and, if it is a directory, everything inside it.
+func (e *executer) RmAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// Report whether path exists. Unlike a bare os.Stat check, any error
+// other than "not exist" is returned instead of being folded into false.
+func (e *executer) Exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,197 @@
+package cmdutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCp_File(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Cp(src, dst); err != nil {
+		t.Fatalf("Cp: %v", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("dst content = %q, want %q", got, "hello")
+	}
+}
+
+func TestCp_DirectoryRequiresRecursive(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "srcdir")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Cp(src, filepath.Join(dir, "dstdir")); err == nil {
+		t.Fatal("expected an error copying a directory without WithRecursive")
+	}
+}
+
+func TestCp_RecursivePreservesMode(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "srcdir")
+	dst := filepath.Join(dir, "dstdir")
+
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Cp(src, dst, WithRecursive(true), WithPreserveMode(true)); err != nil {
+		t.Fatalf("Cp: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("copied file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestMv(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Mv(src, dst); err != nil {
+		t.Fatalf("Mv: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("src still exists after Mv: %v", err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Errorf("dst missing after Mv: %v", err)
+	}
+}
+
+// TestMv_CrossDevice moves a file from the tmpfs at /dev/shm into the
+// test's temp directory, which exercises the EXDEV fallback when the two
+// are on different filesystems. Skips where /dev/shm is unavailable; where
+// both happen to be on the same filesystem, Mv takes the plain rename path
+// and the test still passes.
+func TestMv_CrossDevice(t *testing.T) {
+	shm := "/dev/shm"
+	if info, err := os.Stat(shm); err != nil || !info.IsDir() {
+		t.Skip("no /dev/shm tmpfs available to force a cross-device move")
+	}
+
+	src, err := os.CreateTemp(shm, "cmdutils-mv-src-")
+	if err != nil {
+		t.Skip("cannot create a temp file under /dev/shm:", err)
+	}
+	srcPath := src.Name()
+	defer os.Remove(srcPath)
+
+	if _, err := src.WriteString("hello"); err != nil {
+		t.Fatal(err)
+	}
+	src.Close()
+
+	dst := filepath.Join(t.TempDir(), "dst.txt")
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Mv(srcPath, dst); err != nil {
+		t.Fatalf("Mv across devices: %v", err)
+	}
+
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Errorf("src still exists after cross-device Mv: %v", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("dst missing after cross-device Mv: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("dst content = %q, want %q", got, "hello")
+	}
+}
+
+func TestRmAll_RemovesNonEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "f.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.RmAll(filepath.Join(dir, "a")); err != nil {
+		t.Fatalf("RmAll: %v", err)
+	}
+
+	if exists, _ := e.Exists(filepath.Join(dir, "a")); exists {
+		t.Error("directory still exists after RmAll")
+	}
+}
+
+func TestSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+
+	if err := os.WriteFile(target, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+	if err := e.Symlink(target, link); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	got, err := os.Readlink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != target {
+		t.Errorf("Readlink = %q, want %q", got, target)
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.txt")
+	if err := os.WriteFile(present, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecuter(CLI_AUTO)
+
+	ok, err := e.Exists(present)
+	if err != nil || !ok {
+		t.Errorf("Exists(present) = %v, %v, want true, nil", ok, err)
+	}
+
+	ok, err = e.Exists(filepath.Join(dir, "missing.txt"))
+	if err != nil || ok {
+		t.Errorf("Exists(missing) = %v, %v, want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,36 @@
+package cmdutils
+
+import (
+	"bufio"
+	"io"
+)
+
+// scanLines reads newline-delimited output from pr with a bufio.Scanner,
+// sending each line to output as an OutputMessage (tagged isStderr), and
+// tees the raw bytes into capture when non-nil.
+//
+// If the scanner errors out (most commonly bufio.ErrTooLong, when a line
+// exceeds maxBuf), it closes pr with that error instead of just returning.
+// pr is the read side of an io.Pipe fed by exec.Cmd's internal copy
+// goroutine; if nobody keeps reading from it, that goroutine blocks
+// forever on its next Write and cmd.Wait() never returns, even though the
+// child process has already exited. CloseWithError unblocks the writer
+// immediately by failing its in-flight and future Writes.
+func scanLines(pr *io.PipeReader, isStderr bool, capture io.Writer, output chan<- OutputMessage, bufSize, maxBuf int) {
+	var r io.Reader = pr
+	if capture != nil {
+		r = io.TeeReader(pr, capture)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, bufSize), maxBuf)
+
+	for scanner.Scan() {
+		output <- OutputMessage{Line: scanner.Text(), IsStderr: isStderr}
+	}
+
+	if err := scanner.Err(); err != nil {
+		output <- OutputMessage{Error: err}
+		pr.CloseWithError(err)
+	}
+}
@@ -0,0 +1,49 @@
+package cmdutils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestAsyncExecute_OversizedLineDoesNotHang reproduces the deadlock where a
+// line longer than the scanner's max buffer left exec.Cmd's internal copy
+// goroutine blocked on an undrained io.Pipe, so cmd.Wait() (and therefore
+// the output channel close) never happened even though the child had
+// already exited.
+func TestAsyncExecute_OversizedLineDoesNotHang(t *testing.T) {
+	e := NewExecuter(CLI_BASH)
+
+	messages, err := e.AsyncExecuteContext(context.Background(),
+		fmt.Sprintf("head -c %d /dev/zero | tr '\\0' 'x'; echo", DefaultMaxScannerBuffer*4),
+		WithScannerBuffer(64, 1024),
+	)
+	if err != nil {
+		t.Fatalf("AsyncExecuteContext: %v", err)
+	}
+
+	done := make(chan struct{})
+	var sawTooLong bool
+
+	go func() {
+		defer close(done)
+		for msg := range messages {
+			if msg.Error != nil && errors.Is(msg.Error, bufio.ErrTooLong) {
+				sawTooLong = true
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("output channel never closed; scan goroutine left cmd.Wait() blocked")
+	}
+
+	if !sawTooLong {
+		t.Error("expected a bufio.ErrTooLong-wrapped error for the oversized line")
+	}
+}
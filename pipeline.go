@@ -0,0 +1,215 @@
+package cmdutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pipeline chains Commands together, wiring each stage's stdout directly
+// into the next stage's stdin via os/exec's pipe support, so callers can
+// express things like `grep foo | sort | uniq -c` without relying on a
+// shell's quoting rules.
+type Pipeline struct {
+	cmds []*Command
+}
+
+// Pipeline builds a Pipeline out of cmds, run in order with stage i's
+// stdout feeding stage i+1's stdin.
+func (e *executer) Pipeline(cmds ...*Command) *Pipeline {
+	return &Pipeline{cmds: cmds}
+}
+
+// StageError is one stage's non-zero exit captured by a Pipeline run.
+type StageError struct {
+	Index int
+	Name  string
+	Err   error
+}
+
+func (s *StageError) Error() string {
+	return fmt.Sprintf("stage %d (%s): %s", s.Index, s.Name, s.Err)
+}
+
+func (s *StageError) Unwrap() error {
+	return s.Err
+}
+
+// PipelineError reports every stage of a Pipeline run that exited
+// non-zero.
+type PipelineError struct {
+	Stages []*StageError
+}
+
+func (p *PipelineError) Error() string {
+	msgs := make([]string, len(p.Stages))
+	for i, s := range p.Stages {
+		msgs[i] = s.Error()
+	}
+
+	return "pipeline: " + strings.Join(msgs, "; ")
+}
+
+func (p *Pipeline) build() ([]*exec.Cmd, error) {
+	if len(p.cmds) == 0 {
+		return nil, fmt.Errorf("pipeline: no commands given")
+	}
+
+	cmds := make([]*exec.Cmd, len(p.cmds))
+
+	for i, c := range p.cmds {
+		cmds[i] = exec.Command(c.Name, c.Args...)
+
+		if c.Env != nil {
+			cmds[i].Env = c.Env
+		}
+		if c.Dir != "" {
+			cmds[i].Dir = c.Dir
+		}
+	}
+
+	if p.cmds[0].Stdin != nil {
+		cmds[0].Stdin = p.cmds[0].Stdin
+	}
+
+	for i := 1; i < len(cmds); i++ {
+		stdout, err := cmds[i-1].StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("pipeline: stage %d (%s): %w", i-1, p.cmds[i-1].Name, err)
+		}
+
+		cmds[i].Stdin = stdout
+	}
+
+	return cmds, nil
+}
+
+func startAll(cmds []*exec.Cmd, names []*Command) error {
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("pipeline: stage %d (%s): %w", i, names[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+func waitAll(cmds []*exec.Cmd, names []*Command) *PipelineError {
+	var stageErrs []*StageError
+
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			stageErrs = append(stageErrs, &StageError{Index: i, Name: names[i].Name, Err: err})
+		}
+	}
+
+	if len(stageErrs) == 0 {
+		return nil
+	}
+
+	return &PipelineError{Stages: stageErrs}
+}
+
+// Run executes every stage and returns the last stage's captured stdout.
+// If one or more stages exit non-zero, the error is a *PipelineError
+// listing each failing stage's index and underlying error.
+func (p *Pipeline) Run() (string, error) {
+	cmds, err := p.build()
+	if err != nil {
+		return "", err
+	}
+
+	var final bytes.Buffer
+	cmds[len(cmds)-1].Stdout = &final
+
+	if err := startAll(cmds, p.cmds); err != nil {
+		return "", err
+	}
+
+	if pipeErr := waitAll(cmds, p.cmds); pipeErr != nil {
+		return final.String(), pipeErr
+	}
+
+	return final.String(), nil
+}
+
+// Stream executes every stage and reports the last stage's stdout line by
+// line on the returned channel, honoring ctx for cancellation. When ctx
+// is canceled, every stage is sent os.Interrupt and, if a stage hasn't
+// exited after KillGrace, Kill.
+func (p *Pipeline) Stream(ctx context.Context) (<-chan OutputMessage, error) {
+	cmds, err := p.build()
+	if err != nil {
+		return nil, err
+	}
+
+	last := cmds[len(cmds)-1]
+
+	// Route the last stage's stdout through io.Pipe rather than
+	// StdoutPipe(): that raw pipe is closed by cmd.Wait() as soon as the
+	// process is reaped, which os/exec documents as unsafe to do before
+	// the scanner goroutine below has drained it. Setting Stdout to an
+	// io.Writer instead makes Wait() block until everything has been
+	// copied into it, so it's safe to Close() the writer right after
+	// waitAll returns.
+	lastStdout, lastStdoutWriter := io.Pipe()
+	last.Stdout = lastStdoutWriter
+
+	if err := startAll(cmds, p.cmds); err != nil {
+		return nil, err
+	}
+
+	output := make(chan OutputMessage, DefaultOutputChanBuffer)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		scanLines(lastStdout, false, nil, output, DefaultScannerBuffer, DefaultMaxScannerBuffer)
+	}()
+
+	procsDone := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, cmd := range cmds {
+				_ = cmd.Process.Signal(os.Interrupt)
+			}
+
+			select {
+			case <-time.After(KillGrace):
+				for _, cmd := range cmds {
+					_ = cmd.Process.Kill()
+				}
+			case <-procsDone:
+			}
+		case <-procsDone:
+		}
+	}()
+
+	go func() {
+		pipeErr := waitAll(cmds, p.cmds)
+		close(procsDone)
+
+		lastStdoutWriter.Close()
+
+		wg.Wait()
+
+		if pipeErr != nil {
+			output <- OutputMessage{Error: pipeErr}
+		}
+
+		close(output)
+	}()
+
+	return output, nil
+}
@@ -0,0 +1,86 @@
+package cmdutils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	e := NewExecuter(CLI_AUTO)
+
+	out, err := e.Pipeline(
+		NewCommand("echo", "-e", "banana\napple\ncherry"),
+		NewCommand("sort"),
+	).Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := "apple\nbanana\ncherry\n"
+	if out != want {
+		t.Errorf("Run() = %q, want %q", out, want)
+	}
+}
+
+func TestPipeline_RunReportsFailingStage(t *testing.T) {
+	e := NewExecuter(CLI_AUTO)
+
+	_, err := e.Pipeline(
+		NewCommand("sh", "-c", "exit 3"),
+		NewCommand("cat"),
+	).Run()
+
+	var pipeErr *PipelineError
+	if !errors.As(err, &pipeErr) {
+		t.Fatalf("Run() error = %v, want *PipelineError", err)
+	}
+	if len(pipeErr.Stages) != 1 || pipeErr.Stages[0].Index != 0 {
+		t.Errorf("Stages = %+v, want exactly stage 0 to have failed", pipeErr.Stages)
+	}
+}
+
+func TestPipeline_Stream(t *testing.T) {
+	e := NewExecuter(CLI_AUTO)
+
+	messages, err := e.Pipeline(
+		NewCommand("echo", "-e", "banana\napple"),
+		NewCommand("sort"),
+	).Stream(context.Background())
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	var lines []string
+	for msg := range messages {
+		if msg.Error != nil {
+			t.Fatalf("unexpected error message: %v", msg.Error)
+		}
+		lines = append(lines, msg.Line)
+	}
+
+	if got := strings.Join(lines, ","); got != "apple,banana" {
+		t.Errorf("lines = %q, want %q", got, "apple,banana")
+	}
+}
+
+func TestPipeline_StreamCancel(t *testing.T) {
+	e := NewExecuter(CLI_AUTO)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	messages, err := e.Pipeline(NewCommand("sleep", "30")).Stream(ctx)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	start := time.Now()
+	for range messages {
+	}
+	if elapsed := time.Since(start); elapsed >= 30*time.Second {
+		t.Fatalf("Stream did not stop early on cancellation, took %s", elapsed)
+	}
+}
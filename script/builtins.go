@@ -0,0 +1,380 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astrica1/cmdutils"
+)
+
+func init() {
+	Register("cd", cdCmd{})
+	Register("mkdir", mkdirCmd{})
+	Register("rm", rmCmd{})
+	Register("cp", cpCmd{})
+	Register("mv", mvCmd{})
+	Register("chmod", chmodCmd{})
+	Register("cat", catCmd{})
+	Register("echo", echoCmd{})
+	Register("env", envCmd{})
+	Register("exec", execCmd{})
+	Register("exists", existsCmd{})
+	Register("grep", grepCmd{})
+	Register("sleep", sleepCmd{})
+	Register("wait", waitCmd{})
+	Register("stdout", stdoutCmd{})
+	Register("stderr", stderrCmd{})
+	Register("stop", stopCmd{})
+}
+
+type cdCmd struct{}
+
+func (cdCmd) Usage() string { return "cd <path>" }
+
+func (cdCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", cdCmd{}.Usage())
+	}
+
+	// cd only ever updates state.Dir, never the real process working
+	// directory: scripts are meant to be run concurrently and reused, and
+	// os.Chdir is process-wide global state that would make one script's
+	// cd leak into every other command running in the same process. Every
+	// built-in that takes a path resolves it against state.Dir via
+	// state.resolvePath instead.
+	state.Dir = resolveDir(state.Dir, args[0])
+
+	return nil, nil
+}
+
+// resolveDir joins a cd argument onto the script's current directory the
+// way a shell would: an absolute path replaces it outright, a relative one
+// is appended. base may be "" (no cd yet, meaning the process's real cwd).
+func resolveDir(base, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if base == "" {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(base, path)
+}
+
+type mkdirCmd struct{}
+
+func (mkdirCmd) Usage() string { return "mkdir <name>" }
+
+func (mkdirCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", mkdirCmd{}.Usage())
+	}
+
+	return nil, state.Exec.Mkdir(state.resolvePath(args[0]))
+}
+
+type rmCmd struct{}
+
+func (rmCmd) Usage() string { return "rm <path>" }
+
+func (rmCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", rmCmd{}.Usage())
+	}
+
+	return nil, state.Exec.Rm(state.resolvePath(args[0]))
+}
+
+type cpCmd struct{}
+
+func (cpCmd) Usage() string { return "cp <src> <dst>" }
+
+func (cpCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("usage: %s", cpCmd{}.Usage())
+	}
+
+	return nil, state.Exec.Cp(state.resolvePath(args[0]), state.resolvePath(args[1]))
+}
+
+type mvCmd struct{}
+
+func (mvCmd) Usage() string { return "mv <src> <dst>" }
+
+func (mvCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("usage: %s", mvCmd{}.Usage())
+	}
+
+	return nil, state.Exec.Mv(state.resolvePath(args[0]), state.resolvePath(args[1]))
+}
+
+type chmodCmd struct{}
+
+func (chmodCmd) Usage() string { return "chmod <mode> <path>" }
+
+func (chmodCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("usage: %s", chmodCmd{}.Usage())
+	}
+
+	owner, group, other, err := parsePermTriplet(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, state.Exec.Chmod(state.resolvePath(args[1]), owner, group, other)
+}
+
+// parsePermTriplet parses a 3-digit octal mode string (e.g. "755") into the
+// owner/group/other PermissionMode triplet cmdutils.Executer.Chmod expects.
+func parsePermTriplet(mode string) (owner, group, other cmdutils.PermissionMode, err error) {
+	if len(mode) != 3 {
+		return 0, 0, 0, fmt.Errorf("chmod: invalid mode %q: want 3 octal digits", mode)
+	}
+
+	digits := make([]cmdutils.PermissionMode, 3)
+	for i, c := range []byte(mode) {
+		if c < '0' || c > '7' {
+			return 0, 0, 0, fmt.Errorf("chmod: invalid mode %q: want 3 octal digits", mode)
+		}
+		digits[i] = cmdutils.PermissionMode(c - '0')
+	}
+
+	return digits[0], digits[1], digits[2], nil
+}
+
+type catCmd struct{}
+
+func (catCmd) Usage() string { return "cat <path>" }
+
+func (catCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", catCmd{}.Usage())
+	}
+
+	content, err := os.ReadFile(state.resolvePath(args[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	state.Stdout = string(content)
+
+	return nil, nil
+}
+
+type echoCmd struct{}
+
+func (echoCmd) Usage() string { return "echo <text...>" }
+
+func (echoCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	state.Stdout = strings.Join(args, " ")
+
+	return nil, nil
+}
+
+type envCmd struct{}
+
+func (envCmd) Usage() string { return "env [key=value]" }
+
+func (envCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) == 0 {
+		state.Stdout = strings.Join(state.Env, "\n")
+
+		return nil, nil
+	}
+
+	if len(args) != 1 || !strings.Contains(args[0], "=") {
+		return nil, fmt.Errorf("usage: %s", envCmd{}.Usage())
+	}
+
+	state.Env = append(state.Env, args[0])
+
+	return nil, nil
+}
+
+type execCmd struct{}
+
+func (execCmd) Usage() string { return "exec <command> [&]" }
+
+func (execCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("usage: %s", execCmd{}.Usage())
+	}
+
+	background := args[len(args)-1] == "&"
+	if background {
+		args = args[:len(args)-1]
+	}
+
+	command := strings.Join(args, " ")
+
+	opts := []cmdutils.ExecOption{cmdutils.WithEnv(state.Env), cmdutils.WithDir(state.Dir)}
+
+	if !background {
+		var stderr strings.Builder
+		out, err := state.Exec.ExecuteContext(context.Background(), command, append(opts, cmdutils.WithStderr(&stderr))...)
+		state.Stdout = out
+		state.Stderr = stderr.String()
+
+		return nil, err
+	}
+
+	messages, err := state.Exec.AsyncExecuteContext(context.Background(), command, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		var stdout, stderr strings.Builder
+		var runErr error
+
+		for msg := range messages {
+			switch {
+			case msg.Error != nil:
+				runErr = msg.Error
+			case msg.IsStderr:
+				stderr.WriteString(msg.Line + "\n")
+			default:
+				stdout.WriteString(msg.Line + "\n")
+			}
+		}
+
+		state.Stdout = stdout.String()
+		state.Stderr = stderr.String()
+
+		return runErr
+	}, nil
+}
+
+type existsCmd struct{}
+
+func (existsCmd) Usage() string { return "exists <path>" }
+
+func (existsCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", existsCmd{}.Usage())
+	}
+
+	exists, err := state.Exec.Exists(state.resolvePath(args[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	state.Stdout = strconv.FormatBool(exists)
+
+	return nil, nil
+}
+
+type grepCmd struct{}
+
+func (grepCmd) Usage() string { return "grep <regexp>" }
+
+func (grepCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", grepCmd{}.Usage())
+	}
+
+	re, err := regexp.Compile(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, line := range strings.Split(state.Stdout, "\n") {
+		if re.MatchString(line) {
+			matched = append(matched, line)
+		}
+	}
+
+	state.Stdout = strings.Join(matched, "\n")
+
+	return nil, nil
+}
+
+type sleepCmd struct{}
+
+func (sleepCmd) Usage() string { return "sleep <duration>" }
+
+func (sleepCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", sleepCmd{}.Usage())
+	}
+
+	d, err := time.ParseDuration(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	time.Sleep(d)
+
+	return nil, nil
+}
+
+type waitCmd struct{}
+
+func (waitCmd) Usage() string { return "wait" }
+
+func (waitCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	pending := state.pending
+	state.pending = nil
+
+	for _, wait := range pending {
+		if err := wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+type stdoutCmd struct{}
+
+func (stdoutCmd) Usage() string { return "stdout <regexp>" }
+
+func (stdoutCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", stdoutCmd{}.Usage())
+	}
+
+	return nil, matchAssert("stdout", state.Stdout, args[0])
+}
+
+type stderrCmd struct{}
+
+func (stderrCmd) Usage() string { return "stderr <regexp>" }
+
+func (stderrCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("usage: %s", stderrCmd{}.Usage())
+	}
+
+	return nil, matchAssert("stderr", state.Stderr, args[0])
+}
+
+func matchAssert(name, captured, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	if !re.MatchString(captured) {
+		return fmt.Errorf("%s: %q does not match %q", name, captured, pattern)
+	}
+
+	return nil
+}
+
+type stopCmd struct{}
+
+func (stopCmd) Usage() string { return "stop" }
+
+func (stopCmd) Run(state *State, args ...string) (WaitFunc, error) {
+	return nil, ErrStop
+}
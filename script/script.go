@@ -0,0 +1,121 @@
+// Package script implements a tiny, shell-agnostic scripting language on
+// top of cmdutils.Executer. Scripts are made of lines of the form
+// `verb arg1 arg2 ...`; verbs are either the built-ins registered in
+// builtins.go or commands added with Register. Every built-in except exec
+// is implemented in Go rather than dispatched to a shell, so apart from
+// the command lines given to exec, a script behaves identically on bash,
+// powershell and cmd.
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/astrica1/cmdutils"
+)
+
+// ErrStop is returned by the `stop` built-in to end a script early without
+// that being treated as a failure.
+var ErrStop = errors.New("script: stop requested")
+
+// WaitFunc is returned by a Cmd that started background work. Calling it
+// blocks until that work finishes and reports its outcome.
+type WaitFunc func() error
+
+// Cmd is a single verb a script can invoke.
+type Cmd interface {
+	// Usage returns a one-line usage string, e.g. "cp <src> <dst>".
+	Usage() string
+
+	// Run executes the command against state with the given arguments.
+	// If the command starts background work, it returns a non-nil
+	// WaitFunc that the `wait` built-in will later call.
+	Run(state *State, args ...string) (WaitFunc, error)
+}
+
+// State is the mutable context threaded through a script run: the current
+// working directory and environment, the Executer used to run commands,
+// and the stdout/stderr captured by the previous command so assertions
+// like `stdout <regexp>` can inspect it.
+type State struct {
+	Exec cmdutils.Executer
+
+	Dir string
+	Env []string
+
+	Stdout string
+	Stderr string
+
+	pending []WaitFunc
+}
+
+// NewState creates a State that runs commands with exec, starting in the
+// current working directory and with the current process environment.
+func NewState(exec cmdutils.Executer) *State {
+	return &State{Exec: exec, Env: os.Environ()}
+}
+
+// resolvePath resolves a path argument given to a path-taking built-in
+// against state.Dir, the same way cd's resolveDir does, so every built-in
+// sees paths relative to the script's current directory rather than the
+// real process cwd.
+func (s *State) resolvePath(path string) string {
+	return resolveDir(s.Dir, path)
+}
+
+var registry = map[string]Cmd{}
+
+// Register adds a user-defined command under name, making it available to
+// any script run afterwards. Registering a name that already exists
+// (including a built-in) replaces it.
+func Register(name string, cmd Cmd) {
+	registry[name] = cmd
+}
+
+// Lookup returns the command registered under name, if any.
+func Lookup(name string) (Cmd, bool) {
+	cmd, ok := registry[name]
+
+	return cmd, ok
+}
+
+// Run parses src line by line and executes each line in order against
+// state. Blank lines and lines starting with # are ignored. Execution
+// stops at the first error; ErrStop from the `stop` built-in is not
+// treated as a failure and causes Run to return nil.
+func Run(src string, state *State) error {
+	scanner := bufio.NewScanner(strings.NewReader(src))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		verb, args := fields[0], fields[1:]
+
+		cmd, ok := Lookup(verb)
+		if !ok {
+			return fmt.Errorf("script: unknown command %q", verb)
+		}
+
+		wait, err := cmd.Run(state, args...)
+		if err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+
+			return fmt.Errorf("script: %q: %w", line, err)
+		}
+
+		if wait != nil {
+			state.pending = append(state.pending, wait)
+		}
+	}
+
+	return scanner.Err()
+}
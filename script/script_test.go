@@ -0,0 +1,135 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/astrica1/cmdutils"
+)
+
+func TestRun_CdAccumulatesAcrossMultipleCalls(t *testing.T) {
+	base := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(base, "foo", "bar"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "foo", "bar", "marker.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewState(cmdutils.NewExecuter(cmdutils.CLI_BASH))
+
+	src := "cd " + filepath.Join(base, "foo") + "\ncd bar\nexec cat marker.txt\n"
+	if err := Run(src, state); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if !strings.Contains(state.Stdout, "hello") {
+		t.Errorf("state.Stdout = %q, want it to contain %q", state.Stdout, "hello")
+	}
+}
+
+func TestRun_CdDoesNotChangeProcessWorkingDirectory(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewState(cmdutils.NewExecuter(cmdutils.CLI_BASH))
+
+	if err := Run("cd "+filepath.Join(base, "sub"), state); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != wd {
+		t.Errorf("process working directory changed from %q to %q; cd must only touch state.Dir", wd, got)
+	}
+}
+
+func TestRun_PathTakingBuiltinsResolveAgainstStateDir(t *testing.T) {
+	base := t.TempDir()
+	sub := filepath.Join(base, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "f.txt"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newState := func() *State { return NewState(cmdutils.NewExecuter(cmdutils.CLI_BASH)) }
+
+	t.Run("mkdir", func(t *testing.T) {
+		state := newState()
+		if err := Run("cd "+sub+"\nmkdir created", state); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(sub, "created")); err != nil {
+			t.Errorf("mkdir did not create the directory under state.Dir: %v", err)
+		}
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		state := newState()
+		if err := Run("cd "+sub+"\nexists f.txt", state); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if state.Stdout != "true" {
+			t.Errorf("exists f.txt = %q, want %q", state.Stdout, "true")
+		}
+	})
+
+	t.Run("cat", func(t *testing.T) {
+		state := newState()
+		if err := Run("cd "+sub+"\ncat f.txt", state); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if state.Stdout != "content" {
+			t.Errorf("cat f.txt = %q, want %q", state.Stdout, "content")
+		}
+	})
+
+	t.Run("cp_mv_chmod_rm", func(t *testing.T) {
+		state := newState()
+		script := "cd " + sub + "\ncp f.txt copy.txt\nmv copy.txt moved.txt\nchmod 600 moved.txt\nrm moved.txt"
+		if err := Run(script, state); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(sub, "copy.txt")); !os.IsNotExist(err) {
+			t.Error("cp+mv: copy.txt should have been moved away")
+		}
+		if _, err := os.Stat(filepath.Join(sub, "moved.txt")); !os.IsNotExist(err) {
+			t.Error("rm: moved.txt should have been removed")
+		}
+	})
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	state := NewState(cmdutils.NewExecuter(cmdutils.CLI_BASH))
+
+	if err := Run("frobnicate", state); err == nil {
+		t.Fatal("expected an error for an unregistered verb")
+	}
+}
+
+func TestRun_Stop(t *testing.T) {
+	state := NewState(cmdutils.NewExecuter(cmdutils.CLI_BASH))
+
+	if err := Run("echo before\nstop\necho after", state); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if state.Stdout != "before" {
+		t.Errorf("state.Stdout = %q, want %q (stop should end the script early)", state.Stdout, "before")
+	}
+}